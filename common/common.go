@@ -35,11 +35,17 @@ const (
 	MPLSNumber = 0x8847
 	IPV6Number = 0x86dd
 
+	PPPoESessionNumber = 0x8864
+	PPPNumber          = 0x880b // PPP payload of enhanced GRE
+
 	SwapIPV4Number = 0x0008
 	SwapARPNumber  = 0x0608
 	SwapVLANNumber = 0x0081
 	SwapMPLSNumber = 0x4788
 	SwapIPV6Number = 0xdd86
+
+	SwapPPPoESessionNumber = 0x6488
+	SwapPPPNumber          = 0x0b88
 )
 
 // Supported L4 types
@@ -48,10 +54,20 @@ const (
 	IPNumber     = 0x04
 	TCPNumber    = 0x06
 	UDPNumber    = 0x11
+	GRENumber    = 0x2f
 	ICMPv6Number = 0x3a
 	NoNextHeader = 0x3b
 )
 
+// IPv6 extension header types
+const (
+	IPv6HopByHopNumber    = 0x00
+	IPv6RoutingNumber     = 0x2b
+	IPv6FragmentNumber    = 0x2c
+	IPv6AuthNumber        = 0x33
+	IPv6DestinationNumber = 0x3c
+)
+
 // Supported ICMP Types
 const (
 	ICMPTypeEchoRequest         uint8 = 8
@@ -82,6 +98,8 @@ const (
 	UDPLen     = 8
 	ARPLen     = 28
 	GTPMinLen  = 8
+	GREMinLen  = 4
+	PPPoELen   = 8
 )
 
 const (
@@ -119,6 +137,15 @@ const (
 	TCPFlagCwr = 0x80
 )
 
+// Constants for values of IPv4 flags and fragment offset which share
+// FragmentOffset field of IPv4 header.
+const (
+	IPv4FlagReserved       = 0x8000 // RFC 3514 "evil bit"
+	IPv4FlagDontFragment   = 0x4000
+	IPv4FlagMoreFragments  = 0x2000
+	IPv4FragmentOffsetMask = 0x1fff
+)
+
 // ErrorCode type for codes of errors
 type ErrorCode int
 
@@ -6,6 +6,7 @@ package packet
 
 import (
 	"fmt"
+	"unsafe"
 
 	"github.com/intel-go/nff-go/common"
 )
@@ -54,6 +55,32 @@ func (hdr *ARPHdr) String() string {
 		hdr.TPA[0], hdr.TPA[1], hdr.TPA[2], hdr.TPA[3])
 }
 
+// IsEthernetIPv4 returns true if ARP header maps IPv4 addresses to
+// Ethernet MAC addresses, so SHA, SPA, THA and TPA fields have
+// expected lengths and can be used.
+func (hdr *ARPHdr) IsEthernetIPv4() bool {
+	return hdr.HType == SwapBytesUint16(1) &&
+		hdr.PType == SwapBytesUint16(common.IPV4Number) &&
+		hdr.HLen == common.EtherAddrLen &&
+		hdr.PLen == common.IPv4AddrLen
+}
+
+// GetARPCheckLen ensures if EtherType is ARP, packet holds whole ARP
+// header and this header is an Ethernet/IPv4 one, then casts L3
+// pointer to ARPHdr type. Returns nil for truncated or unsupported ARP
+// packets. L3 supposed to be parsed before.
+func (packet *Packet) GetARPCheckLen() *ARPHdr {
+	arp := packet.GetARP()
+	if arp == nil {
+		return nil
+	}
+	l3Len := int(packet.GetPacketSegmentLen()) - int(uintptr(packet.L3)-uintptr(unsafe.Pointer(packet.Ether)))
+	if l3Len < common.ARPLen || !arp.IsEthernetIPv4() {
+		return nil
+	}
+	return arp
+}
+
 // initARPCommonData allocates ARP packet, fills ether header and
 // arp hrd, pro, hln, pln with values for ether and IPv4
 func initARPCommonData(packet *Packet) bool {
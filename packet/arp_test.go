@@ -155,3 +155,29 @@ func TestInitEmptyARPPacket(t *testing.T) {
 		t.Errorf("Incorrect result:\ngot:  %x, \nwant: %x\n\n", buf, gtBuf)
 	}
 }
+
+func TestGetARPCheckLen(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		valid bool
+	}{
+		{"request", gtLineARPRequest, true},
+		{"reply", gtLineARPReply, true},
+		{"truncated", gtLineARPRequest[:len(gtLineARPRequest)-8], false},
+		// Hardware type 6 (IEEE 802) instead of Ethernet
+		{"unexpected hardware type", "ffffffffffff00070daff4540806000608000604000100070daff45418a6ac0100000000000018a6ad9f", false},
+		// Hardware address length 8 instead of 6
+		{"unexpected address length", "ffffffffffff00070daff4540806000108000804000100070daff45418a6ac0100000000000018a6ad9f", false},
+		{"not ARP", gtLineIPv4TCP, false},
+	}
+	for _, test := range tests {
+		buf, _ := hex.DecodeString(test.line)
+		pkt := getPacket()
+		GeneratePacketFromByte(pkt, buf)
+		pkt.ParseL3()
+		if arp := pkt.GetARPCheckLen(); (arp != nil) != test.valid {
+			t.Errorf("%s: incorrect result:\ngot:  %t, \nwant: %t\n\n", test.name, arp != nil, test.valid)
+		}
+	}
+}
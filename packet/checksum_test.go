@@ -6,9 +6,11 @@ package packet
 
 import (
 	"encoding/binary"
+	"encoding/hex"
 	"github.com/intel-go/nff-go/common"
 	"net"
 	"testing"
+	"unsafe"
 )
 
 const N uint = 20
@@ -157,6 +159,76 @@ func TestCalculateIPv6ICMPChecksum(t *testing.T) {
 	}
 }
 
+// UDP checksum which is calculated as zero should be transmitted as
+// all ones because zero means that checksum is not computed. Payload
+// of test packets is chosen to get zero checksum.
+func TestCalculateUDPChecksumZero(t *testing.T) {
+	pkt := getPacket()
+	InitEmptyIPv4UDPPacket(pkt, 2)
+	initIPv4AddrsLocal(pkt)
+	initPorts(pkt)
+	binary.BigEndian.PutUint16((*[2]byte)(pkt.Data)[:], 0x9d15)
+
+	if got := CalculateIPv4UDPChecksum(pkt.GetIPv4(), pkt.GetUDPForIPv4(), pkt.Data); got != 0xffff {
+		t.Errorf("Incorrect IPv4 result:\ngot: %x, \nwant: %x\n\n", got, 0xffff)
+	}
+
+	pkt = getPacket()
+	InitEmptyIPv6UDPPacket(pkt, 2)
+	initIPv6AddrsLocal(pkt)
+	initPorts(pkt)
+	binary.BigEndian.PutUint16((*[2]byte)(pkt.Data)[:], 0x8953)
+
+	if got := CalculateIPv6UDPChecksum(pkt.GetIPv6(), pkt.GetUDPForIPv6(), pkt.Data); got != 0xffff {
+		t.Errorf("Incorrect IPv6 result:\ngot: %x, \nwant: %x\n\n", got, 0xffff)
+	}
+}
+
+// Short frames are padded to minimal Ethernet frame size. Padding
+// bytes shouldn't be included into L4 checksums which are calculated
+// over IPv4 total length bytes only.
+func TestCalculateIPv4ChecksumPaddedFrame(t *testing.T) {
+	const ether = "0011223344550111213141510800"
+	tests := []struct {
+		name string
+		line string
+		want uint16 // calculated over IPv4 total length bytes only
+	}{
+		{
+			name: "UDP",
+			line: ether + "4500001e00000000401100008397201583972081" + "04d2162e000a0000abcd" +
+				"5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a",
+			want: 0xf147,
+		},
+		{
+			name: "TCP",
+			line: ether + "4500002a00000000400600008397201583972081" +
+				"04d2162e00000001000000005018200000000000abcd" + "5a5a5a5a",
+			want: 0x8137,
+		},
+	}
+	for _, test := range tests {
+		buf, _ := hex.DecodeString(test.line)
+		if len(buf) != 60 {
+			t.Fatalf("%s: test frame should have minimal Ethernet size, got %d", test.name, len(buf))
+		}
+		pkt := getPacket()
+		GeneratePacketFromByte(pkt, buf)
+		ipv4, _, _ := pkt.ParseAllKnownL3()
+		tcp, udp, _ := pkt.ParseAllKnownL4ForIPv4()
+
+		var got uint16
+		if udp != nil {
+			got = CalculateIPv4UDPChecksum(ipv4, udp, unsafe.Pointer(uintptr(pkt.L4)+common.UDPLen))
+		} else {
+			got = CalculateIPv4TCPChecksum(ipv4, tcp, unsafe.Pointer(uintptr(pkt.L4)+common.TCPMinLen))
+		}
+		if got != test.want {
+			t.Errorf("%s: incorrect result:\ngot: %x, \nwant: %x\n\n", test.name, got, test.want)
+		}
+	}
+}
+
 func initIPv4AddrsLocal(pkt *Packet) {
 	ipv4 := pkt.GetIPv4()
 	ipv4.SrcAddr = binary.LittleEndian.Uint32(net.ParseIP("131.151.32.21").To4())
@@ -0,0 +1,150 @@
+// Copyright 2019 Intel Corporation.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package packet
+
+import (
+	"fmt"
+	"unsafe"
+
+	. "github.com/intel-go/nff-go/common"
+)
+
+// GREHdr is the mandatory part of GRE header (RFC 2784). Optional
+// checksum, key and sequence number fields (RFC 2890) and
+// acknowledgment number of enhanced GRE used by PPTP (RFC 2637)
+// follow it in this order if corresponding flags are set.
+type GREHdr struct {
+	Flags    uint16 // C, R, K, S, s, Recur, A, Flags and Ver bit-fields
+	Protocol uint16 // EtherType of encapsulated payload
+}
+
+// GRE flags and versions
+const (
+	GREFlagChecksum = 0x8000
+	GREFlagRouting  = 0x4000
+	GREFlagKey      = 0x2000
+	GREFlagSequence = 0x1000
+	GREFlagAck      = 0x0080
+	GREVersionMask  = 0x0007
+
+	GREVersion     = 0
+	GREVersionPPTP = 1
+)
+
+func (hdr *GREHdr) String() string {
+	r := fmt.Sprintf(`        L4 protocol: GRE, Version: %d, Protocol: 0x%04x`,
+		hdr.GetGREVersion(), SwapBytesUint16(hdr.Protocol))
+	if key, ok := hdr.GetGREKey(); ok {
+		r += fmt.Sprintf(`, Key: 0x%08x`, key)
+	}
+	return r + "\n"
+}
+
+// GetGREVersion returns Ver field of GRE header.
+func (hdr *GREHdr) GetGREVersion() uint8 {
+	return uint8(SwapBytesUint16(hdr.Flags) & GREVersionMask)
+}
+
+// GetGRELen returns length of GRE header including all optional
+// fields which are present according to its flags. Acknowledgment
+// number is counted only for enhanced GRE, in version 0 its flag is
+// reserved. Second returned value is false if routing flag is set
+// because length of RFC 1701 routing entries which follow fixed
+// fields can't be computed from flags.
+func (hdr *GREHdr) GetGRELen() (uint, bool) {
+	flags := SwapBytesUint16(hdr.Flags)
+	if flags&GREFlagRouting != 0 {
+		return 0, false
+	}
+	length := uint(GREMinLen)
+	if flags&GREFlagChecksum != 0 {
+		length += 4
+	}
+	if flags&GREFlagKey != 0 {
+		length += 4
+	}
+	if flags&GREFlagSequence != 0 {
+		length += 4
+	}
+	if flags&GREFlagAck != 0 && hdr.GetGREVersion() == GREVersionPPTP {
+		length += 4
+	}
+	return length, true
+}
+
+// getGREKeyPointer returns pointer to key field or nil if key is
+// not present in header.
+func (hdr *GREHdr) getGREKeyPointer() *uint32 {
+	flags := SwapBytesUint16(hdr.Flags)
+	if flags&GREFlagKey == 0 {
+		return nil
+	}
+	offset := uintptr(GREMinLen)
+	if flags&(GREFlagChecksum|GREFlagRouting) != 0 {
+		offset += 4
+	}
+	return (*uint32)(unsafe.Pointer(uintptr(unsafe.Pointer(hdr)) + offset))
+}
+
+// GetGREKey returns key field of GRE header in host byte order.
+// Second returned value is false if key is not present.
+func (hdr *GREHdr) GetGREKey() (uint32, bool) {
+	key := hdr.getGREKeyPointer()
+	if key == nil {
+		return 0, false
+	}
+	return SwapBytesUint32(*key), true
+}
+
+// SetGREKey sets key field of GRE header. Returns false if key
+// is not present in header.
+func (hdr *GREHdr) SetGREKey(value uint32) bool {
+	key := hdr.getGREKeyPointer()
+	if key == nil {
+		return false
+	}
+	*key = SwapBytesUint32(value)
+	return true
+}
+
+// GetPPTPCallID returns Call ID of enhanced GRE header used by PPTP.
+// It is located in low 16 bits of key field while high 16 bits keep
+// payload length. Second returned value is false if header is not an
+// enhanced GRE header with PPP payload.
+func (hdr *GREHdr) GetPPTPCallID() (uint16, bool) {
+	if hdr.GetGREVersion() != GREVersionPPTP || hdr.Protocol != SwapBytesUint16(PPPNumber) {
+		return 0, false
+	}
+	key, ok := hdr.GetGREKey()
+	return uint16(key), ok
+}
+
+// SetPPTPCallID sets Call ID of enhanced GRE header used by PPTP
+// keeping payload length unchanged. Returns false if header is not
+// an enhanced GRE header with PPP payload.
+func (hdr *GREHdr) SetPPTPCallID(callID uint16) bool {
+	if hdr.GetGREVersion() != GREVersionPPTP || hdr.Protocol != SwapBytesUint16(PPPNumber) {
+		return false
+	}
+	key, ok := hdr.GetGREKey()
+	if !ok {
+		return false
+	}
+	return hdr.SetGREKey(key&0xffff0000 | uint32(callID))
+}
+
+// GetGREForIPv4 ensures if L4 type is GRE and casts L4 pointer to
+// *GREHdr type. L3 supposed to be parsed before and of IPv4 type.
+func (packet *Packet) GetGREForIPv4() *GREHdr {
+	if packet.GetIPv4NoCheck().NextProtoID == GRENumber {
+		return (*GREHdr)(packet.L4)
+	}
+	return nil
+}
+
+// GetGRENoCheck casts L4 pointer to *GREHdr type.
+func (packet *Packet) GetGRENoCheck() *GREHdr {
+	return (*GREHdr)(packet.L4)
+}
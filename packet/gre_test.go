@@ -0,0 +1,91 @@
+// Copyright 2019 Intel Corporation.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package packet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	. "github.com/intel-go/nff-go/common"
+)
+
+func init() {
+	tInitDPDK()
+}
+
+// PPTP enhanced GRE packet with key and sequence number fields,
+// payload length 4 and Call ID 0x1234
+var gtLineIPv4PPTPGRE = "00112233445501112131415108004500002400000000402f0000c0a80001c0a80101" +
+	"3001880b0004123400000001ff030021"
+
+func TestPPTPGRE(t *testing.T) {
+	buf, _ := hex.DecodeString(gtLineIPv4PPTPGRE)
+	pkt := getPacket()
+	GeneratePacketFromByte(pkt, buf)
+
+	pkt.ParseL3()
+	if _, _, _, ok := pkt.ParseAllKnownL4ForIPv4CheckLen(); !ok {
+		t.Error("Correct packet was reported as truncated")
+	}
+	pkt.ParseL4ForIPv4()
+	gre := pkt.GetGREForIPv4()
+	if gre == nil {
+		t.Fatal("GRE header was not recognized")
+	}
+	if gre.GetGREVersion() != GREVersionPPTP {
+		t.Errorf("Incorrect version:\ngot:  %d, \nwant: %d\n\n", gre.GetGREVersion(), GREVersionPPTP)
+	}
+	if length, ok := gre.GetGRELen(); !ok || length != GREMinLen+8 {
+		t.Errorf("Incorrect header length:\ngot:  %d (%t), \nwant: %d\n\n", length, ok, GREMinLen+8)
+	}
+	callID, ok := gre.GetPPTPCallID()
+	if !ok || callID != 0x1234 {
+		t.Errorf("Incorrect Call ID:\ngot:  0x%x (%t), \nwant: 0x1234\n\n", callID, ok)
+	}
+
+	if !gre.SetPPTPCallID(0xabcd) {
+		t.Fatal("SetPPTPCallID failed")
+	}
+	key, _ := gre.GetGREKey()
+	if key != 0x0004abcd {
+		t.Errorf("Payload length should be kept:\ngot:  0x%08x, \nwant: 0x0004abcd\n\n", key)
+	}
+}
+
+func TestGREWithoutKey(t *testing.T) {
+	// Plain GRE header with checksum field and IPv4 payload
+	buf, _ := hex.DecodeString("00112233445501112131415108004500001c00000000402f0000c0a80001c0a80101" +
+		"8000080000000000")
+	pkt := getPacket()
+	GeneratePacketFromByte(pkt, buf)
+
+	pkt.ParseL3()
+	pkt.ParseL4ForIPv4()
+	gre := pkt.GetGRENoCheck()
+	if length, ok := gre.GetGRELen(); !ok || length != GREMinLen+4 {
+		t.Errorf("Incorrect header length:\ngot:  %d (%t), \nwant: %d\n\n", length, ok, GREMinLen+4)
+	}
+	if _, ok := gre.GetGREKey(); ok {
+		t.Error("Key should not be present")
+	}
+	if _, ok := gre.GetPPTPCallID(); ok {
+		t.Error("Call ID should not be present in GRE version 0")
+	}
+	if gre.SetGREKey(1) {
+		t.Error("SetGREKey should fail without key field")
+	}
+
+	// Acknowledgment flag is reserved in version 0 and doesn't add a field
+	gre.Flags = SwapBytesUint16(GREFlagChecksum | GREFlagAck)
+	if length, ok := gre.GetGRELen(); !ok || length != GREMinLen+4 {
+		t.Errorf("Incorrect header length with reserved flag:\ngot:  %d (%t), \nwant: %d\n\n", length, ok, GREMinLen+4)
+	}
+
+	// Length of routing entries can't be computed
+	gre.Flags = SwapBytesUint16(GREFlagChecksum | GREFlagRouting)
+	if _, ok := gre.GetGRELen(); ok {
+		t.Error("Header length with routing flag shouldn't be computed")
+	}
+}
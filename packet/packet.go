@@ -8,7 +8,8 @@
 //      * L2 Ethernet
 //      * L3 IPv4 and IPv6
 //      * L4 TCP, UDP and ICMP
-// At the moment IPv6 is supported without extension headers.
+// IPv6 extension headers are skipped only by ParseL4ForIPv6ExtHeaders
+// and ParseAllKnownL4ForIPv6ExtHeaders functions.
 //
 // For performance
 // reasons NFF-GO provides a set of functions each of them parse exact network level.
@@ -91,6 +92,8 @@ var (
 		SwapVLANNumber: "VLAN",
 		SwapMPLSNumber: "MPLS",
 		SwapIPV6Number: "IPv6",
+
+		SwapPPPoESessionNumber: "PPPoE session",
 	}
 )
 
@@ -132,6 +135,19 @@ func (hdr *IPv4Hdr) String() string {
 	return r0 + r1 + r2
 }
 
+// CheckIPv4Flags returns false if reserved flag of IPv4 header is set
+// or if "don't fragment" and "more fragments" flags are set together.
+func (hdr *IPv4Hdr) CheckIPv4Flags() bool {
+	flags := SwapBytesUint16(hdr.FragmentOffset)
+	if flags&IPv4FlagReserved != 0 {
+		return false
+	}
+	if flags&(IPv4FlagDontFragment|IPv4FlagMoreFragments) == IPv4FlagDontFragment|IPv4FlagMoreFragments {
+		return false
+	}
+	return true
+}
+
 // IPv6Hdr L3 header from DPDK: lib/librte_net/rte_ip.h
 type IPv6Hdr struct {
 	VtcFlow    uint32             // IP version, traffic class & flow label
@@ -300,6 +316,48 @@ func (packet *Packet) ParseL4ForIPv6() {
 	packet.L4 = unsafe.Pointer(uintptr(packet.L3) + uintptr(IPv6Len))
 }
 
+// ParseL4ForIPv6ExtHeaders set L4 to start of L4 header, if L3 protocol
+// is IPv6, skipping Hop-by-Hop, Routing, Fragment, Destination Options
+// and Authentication extension headers. Returns L4 protocol and offset
+// of L4 header from start of L3 header. Unknown protocol is returned
+// as is with L4 pointing to it. NoNextHeader is returned if there is
+// no L4 header to parse: it is explicitly absent, packet is not the
+// first fragment or extension headers don't fit in packet.
+func (packet *Packet) ParseL4ForIPv6ExtHeaders() (uint8, uint) {
+	proto := packet.GetIPv6NoCheck().Proto
+	offset := uint(IPv6Len)
+	l3Len := packet.GetPacketSegmentLen() - uint(uintptr(packet.L3)-uintptr(unsafe.Pointer(packet.Ether)))
+	for {
+		packet.L4 = unsafe.Pointer(uintptr(packet.L3) + uintptr(offset))
+		var length uint
+		switch proto {
+		case IPv6HopByHopNumber, IPv6RoutingNumber, IPv6DestinationNumber:
+			if offset+2 > l3Len {
+				return NoNextHeader, offset
+			}
+			length = (uint(*(*uint8)(unsafe.Pointer(uintptr(packet.L4) + 1))) + 1) << 3
+		case IPv6FragmentNumber:
+			length = 8
+		case IPv6AuthNumber:
+			if offset+2 > l3Len {
+				return NoNextHeader, offset
+			}
+			length = (uint(*(*uint8)(unsafe.Pointer(uintptr(packet.L4) + 1))) + 2) << 2
+		default:
+			return proto, offset
+		}
+		if offset+length > l3Len {
+			return NoNextHeader, offset
+		}
+		if proto == IPv6FragmentNumber &&
+			SwapBytesUint16(*(*uint16)(unsafe.Pointer(uintptr(packet.L4) + 2)))&0xfff8 != 0 {
+			return NoNextHeader, offset
+		}
+		proto = *(*uint8)(packet.L4)
+		offset += length
+	}
+}
+
 // GetTCPForIPv4 ensures if L4 type is TCP and cast L4 pointer to TCPHdr type.
 func (packet *Packet) GetTCPForIPv4() *TCPHdr {
 	if packet.GetIPv4NoCheck().NextProtoID == TCPNumber {
@@ -391,6 +449,53 @@ func (packet *Packet) ParseAllKnownL4ForIPv4() (*TCPHdr, *UDPHdr, *ICMPHdr) {
 	return nil, nil, nil
 }
 
+// ParseAllKnownL4ForIPv4CheckLen parses L4 field if L3 type is IPv4 like
+// ParseAllKnownL4ForIPv4 does, but firstly ensures that packet is not
+// truncated: it should hold IPv4 header with options, all bytes claimed
+// by IPv4 total length field and whole TCP, UDP or ICMP header. IPv4 and
+// L4 headers should be located in the first segment of packet while
+// total length is checked against length of all segments. Last returned
+// value is false and headers are nil for truncated packets. Fragments
+// except the first one have no L4 header, so for them headers are nil
+// while last returned value is true.
+func (packet *Packet) ParseAllKnownL4ForIPv4CheckLen() (*TCPHdr, *UDPHdr, *ICMPHdr, bool) {
+	l3Offset := int(uintptr(packet.L3) - uintptr(unsafe.Pointer(packet.Ether)))
+	segLen := int(packet.GetPacketSegmentLen()) - l3Offset
+	if segLen < IPv4MinLen {
+		return nil, nil, nil, false
+	}
+	ipv4 := packet.GetIPv4NoCheck()
+	hdrLen := int(ipv4.VersionIhl&0x0f) << 2
+	totalLen := int(SwapBytesUint16(ipv4.TotalLength))
+	if hdrLen < IPv4MinLen || hdrLen > segLen || totalLen < hdrLen ||
+		totalLen > int(packet.GetPacketLen())-l3Offset {
+		return nil, nil, nil, false
+	}
+	if SwapBytesUint16(ipv4.FragmentOffset)&IPv4FragmentOffsetMask != 0 {
+		return nil, nil, nil, true
+	}
+	// L4 header should fit both in IPv4 packet and in first segment
+	l4Len := totalLen - hdrLen
+	if segLen-hdrLen < l4Len {
+		l4Len = segLen - hdrLen
+	}
+	tcp, udp, icmp := packet.ParseAllKnownL4ForIPv4()
+	if tcp != nil {
+		if l4Len < TCPMinLen || tcp.GetTCPHdrLen() < TCPMinLen || l4Len < int(tcp.GetTCPHdrLen()) {
+			return nil, nil, nil, false
+		}
+	} else if udp != nil {
+		if l4Len < UDPLen {
+			return nil, nil, nil, false
+		}
+	} else if icmp != nil {
+		if l4Len < ICMPLen {
+			return nil, nil, nil, false
+		}
+	}
+	return tcp, udp, icmp, true
+}
+
 // ParseAllKnownL4ForIPv6 parses L4 field if L3 type is IPv6 and returns pointers to parsed headers.
 func (packet *Packet) ParseAllKnownL4ForIPv6() (*TCPHdr, *UDPHdr, *ICMPHdr) {
 	packet.ParseL4ForIPv6()
@@ -404,6 +509,21 @@ func (packet *Packet) ParseAllKnownL4ForIPv6() (*TCPHdr, *UDPHdr, *ICMPHdr) {
 	return nil, nil, nil
 }
 
+// ParseAllKnownL4ForIPv6ExtHeaders parses L4 field if L3 type is IPv6
+// skipping extension headers and returns pointers to parsed headers.
+func (packet *Packet) ParseAllKnownL4ForIPv6ExtHeaders() (*TCPHdr, *UDPHdr, *ICMPHdr) {
+	proto, _ := packet.ParseL4ForIPv6ExtHeaders()
+	switch proto {
+	case TCPNumber:
+		return packet.GetTCPNoCheck(), nil, nil
+	case UDPNumber:
+		return nil, packet.GetUDPNoCheck(), nil
+	case ICMPv6Number:
+		return nil, nil, packet.GetICMPNoCheck()
+	}
+	return nil, nil, nil
+}
+
 // ParseL7 fills pointers to all supported headers and data field.
 func (packet *Packet) ParseL7(protocol uint) {
 	switch protocol {
@@ -735,13 +855,21 @@ func (packet *Packet) GetPacketSegmentLen() uint {
 // GetPacketPayload returns extracted packet payload as byte array and bool status.
 // Works only for protocols, supported by ParseData (IPv4, IPv6, TCP, UDP, ICMP). Not zero-copy.
 func (packet *Packet) GetPacketPayload() ([]byte, bool) {
-	pktStartAddr := packet.StartAtOffset(0)
-	pktBytes := packet.GetRawPacketBytes()
-	if packet.ParseData() == -1 {
+	hdrsLen, ok := packet.GetPacketPayloadOffset()
+	if !ok {
 		return []byte{}, false
 	}
-	hdrsLen := uintptr(packet.Data) - uintptr(pktStartAddr)
-	return pktBytes[hdrsLen:], true
+	return packet.GetRawPacketBytes()[hdrsLen:], true
+}
+
+// GetPacketPayloadOffset returns offset of payload from packet start,
+// i.e. summary length of L2, L3 and L4 headers. Second returned value
+// is false if L3 or L4 can't be parsed.
+func (packet *Packet) GetPacketPayloadOffset() (uint, bool) {
+	if packet.ParseData() == -1 {
+		return 0, false
+	}
+	return uint(uintptr(packet.Data) - uintptr(packet.StartAtOffset(0))), true
 }
 
 // EncapsulateHead adds bytes to packet. start - number of beginning byte, length - number of
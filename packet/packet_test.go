@@ -653,3 +653,222 @@ type plTest struct {
 	payload string
 	status  bool
 }
+
+// IPv6 header with given payload length and next header followed by
+// extension headers and L4 header.
+const (
+	gtIPv6EtherHdr = "00112233445501112131415186dd"
+	gtIPv6Addrs    = "dead0000000000000000000000000001dead0000000000000000000000000002"
+	gtIPv6TCPHdr   = "04d2162e00000001000000005002200000000000"
+)
+
+func getIPv6ExtHeadersTestPacket(t *testing.T, payloadLen, nextHeader string, extHdrs ...string) *Packet {
+	line := gtIPv6EtherHdr + "60000000" + payloadLen + nextHeader + "40" + gtIPv6Addrs
+	for _, hdr := range extHdrs {
+		line += hdr
+	}
+	buf, err := hex.DecodeString(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkt := getPacket()
+	GeneratePacketFromByte(pkt, buf)
+	pkt.ParseL3()
+	return pkt
+}
+
+func TestParseL4ForIPv6ExtHeaders(t *testing.T) {
+	pkt := getIPv6ExtHeadersTestPacket(t, "0034", "00",
+		"2b00010400000000", // Hop-by-Hop with PadN, next is Routing
+		"2c00000000000000", // Routing, next is Fragment
+		"3c000000deadbeef", // first Fragment, next is Destination Options
+		"0600010400000000", // Destination Options with PadN, next is TCP
+		gtIPv6TCPHdr)       // TCP
+
+	proto, offset := pkt.ParseL4ForIPv6ExtHeaders()
+	if proto != TCPNumber || offset != IPv6Len+32 {
+		t.Errorf("Incorrect result:\ngot:  proto %d offset %d, \nwant: proto %d offset %d\n\n", proto, offset, TCPNumber, IPv6Len+32)
+	}
+	tcp, udp, icmp := pkt.ParseAllKnownL4ForIPv6ExtHeaders()
+	if tcp == nil || udp != nil || icmp != nil {
+		t.Fatal("TCP header was not recognized after extension headers")
+	}
+	if SwapBytesUint16(tcp.SrcPort) != 1234 || SwapBytesUint16(tcp.DstPort) != 5678 {
+		t.Errorf("Incorrect ports:\ngot:  %d %d, \nwant: 1234 5678\n\n", SwapBytesUint16(tcp.SrcPort), SwapBytesUint16(tcp.DstPort))
+	}
+
+	// Without extension headers result is the same as ParseL4ForIPv6 gives
+	pkt = getIPv6ExtHeadersTestPacket(t, "0014", "06", gtIPv6TCPHdr)
+	proto, offset = pkt.ParseL4ForIPv6ExtHeaders()
+	l4 := pkt.L4
+	pkt.ParseL4ForIPv6()
+	if proto != TCPNumber || offset != IPv6Len || l4 != pkt.L4 {
+		t.Errorf("Incorrect result without extension headers: proto %d offset %d", proto, offset)
+	}
+}
+
+func TestParseL4ForIPv6ExtHeadersNoL4(t *testing.T) {
+	tests := []struct {
+		name   string
+		pkt    *Packet
+		proto  uint8
+		offset uint
+	}{
+		{
+			name:   "unknown next header",
+			pkt:    getIPv6ExtHeadersTestPacket(t, "0010", "00", "fd00010400000000", "0000000000000000"),
+			proto:  0xfd,
+			offset: IPv6Len + 8,
+		},
+		{
+			name:   "not first fragment",
+			pkt:    getIPv6ExtHeadersTestPacket(t, "001c", "2c", "06000100deadbeef", gtIPv6TCPHdr),
+			proto:  NoNextHeader,
+			offset: IPv6Len,
+		},
+		{
+			name:   "truncated extension header",
+			pkt:    getIPv6ExtHeadersTestPacket(t, "0008", "00", "0602010400000000"),
+			proto:  NoNextHeader,
+			offset: IPv6Len,
+		},
+		{
+			name:   "no next header",
+			pkt:    getIPv6ExtHeadersTestPacket(t, "0008", "3c", "3b00010400000000"),
+			proto:  NoNextHeader,
+			offset: IPv6Len + 8,
+		},
+	}
+	for _, test := range tests {
+		proto, offset := test.pkt.ParseL4ForIPv6ExtHeaders()
+		if proto != test.proto || offset != test.offset {
+			t.Errorf("%s: incorrect result:\ngot:  proto %d offset %d, \nwant: proto %d offset %d\n\n",
+				test.name, proto, offset, test.proto, test.offset)
+		}
+		tcp, udp, icmp := test.pkt.ParseAllKnownL4ForIPv6ExtHeaders()
+		if tcp != nil || udp != nil || icmp != nil {
+			t.Errorf("%s: no L4 header should be returned", test.name)
+		}
+	}
+}
+
+func TestParseAllKnownL4ForIPv4CheckLen(t *testing.T) {
+	const ether = "0011223344550111213141510800"
+	tests := []struct {
+		name string
+		line string
+		ok   bool
+		noL4 bool
+	}{
+		{
+			name: "correct TCP",
+			line: ether + "4500002800000000400600008397201583972081" +
+				"04d2162e00000001000000005002200000000000",
+			ok: true,
+		},
+		{
+			name: "correct UDP with Ethernet padding",
+			line: ether + "4500001c00000000401100008397201583972081" + "04d2162e00080000" +
+				"000000000000000000000000000000000000",
+			ok: true,
+		},
+		{
+			name: "truncated TCP header",
+			line: ether + "4500002800000000400600008397201583972081" + "04d2162e0000000100",
+			ok:   false,
+		},
+		{
+			name: "TCP header longer than IPv4 length",
+			line: ether + "4500001d00000000400600008397201583972081" + "04d2162e0000000100",
+			ok:   false,
+		},
+		{
+			name: "UDP header longer than IPv4 length",
+			line: ether + "4500001800000000401100008397201583972081" + "04d2162e",
+			ok:   false,
+		},
+		{
+			name: "ICMP header longer than IPv4 length",
+			line: ether + "4500001800000000400100008397201583972081" + "08000000",
+			ok:   false,
+		},
+		{
+			name: "TCP data offset less than minimal",
+			line: ether + "4500002800000000400600008397201583972081" +
+				"04d2162e00000001000000001002200000000000",
+			ok: false,
+		},
+		{
+			name: "last fragment without L4 header",
+			line: ether + "4500001e000000b9400600008397201583972081" + "04d2162e000000010000",
+			ok:   true,
+			noL4: true,
+		},
+		{
+			name: "claimed length exceeds buffer",
+			line: ether + "4500010000000000401100008397201583972081" + "04d2162e00080000",
+			ok:   false,
+		},
+		{
+			name: "TCP data offset exceeds IPv4 length",
+			line: ether + "4500002800000000400600008397201583972081" +
+				"04d2162e00000001000000008002200000000000",
+			ok: false,
+		},
+		{
+			name: "IPv4 header length less than minimal",
+			line: ether + "4400002800000000400600008397201583972081" +
+				"04d2162e00000001000000005002200000000000",
+			ok: false,
+		},
+		{
+			name: "truncated IPv4 header",
+			line: ether + "450000280000000040060000",
+			ok:   false,
+		},
+	}
+	for _, test := range tests {
+		buf, err := hex.DecodeString(test.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkt := getPacket()
+		GeneratePacketFromByte(pkt, buf)
+		pkt.ParseL3()
+
+		tcp, udp, icmp, ok := pkt.ParseAllKnownL4ForIPv4CheckLen()
+		if ok != test.ok {
+			t.Errorf("%s: incorrect result:\ngot:  %t, \nwant: %t\n\n", test.name, ok, test.ok)
+		}
+		if (ok && !test.noL4) == (tcp == nil && udp == nil && icmp == nil) {
+			t.Errorf("%s: headers should be returned only for not truncated packets with L4 header", test.name)
+		}
+	}
+}
+
+func TestCheckIPv4Flags(t *testing.T) {
+	tests := []struct {
+		name           string
+		fragmentOffset uint16
+		ok             bool
+	}{
+		{"no flags", 0, true},
+		{"don't fragment", IPv4FlagDontFragment, true},
+		{"first fragment", IPv4FlagMoreFragments, true},
+		{"last fragment", 0x00b9, true},
+		{"middle fragment", IPv4FlagMoreFragments | 0x00b9, true},
+		{"reserved flag", IPv4FlagReserved, false},
+		{"reserved flag with don't fragment", IPv4FlagReserved | IPv4FlagDontFragment, false},
+		{"don't fragment with more fragments", IPv4FlagDontFragment | IPv4FlagMoreFragments, false},
+		{"don't fragment with offset", IPv4FlagDontFragment | 0x00b9, true},
+	}
+	pkt := getPacket()
+	InitEmptyIPv4Packet(pkt, 0)
+	ipv4 := pkt.GetIPv4NoCheck()
+	for _, test := range tests {
+		ipv4.FragmentOffset = SwapBytesUint16(test.fragmentOffset)
+		if ok := ipv4.CheckIPv4Flags(); ok != test.ok {
+			t.Errorf("%s: incorrect result:\ngot:  %t, \nwant: %t\n\n", test.name, ok, test.ok)
+		}
+	}
+}
@@ -7,6 +7,7 @@ package packet
 import (
 	"encoding/binary"
 	"io"
+	"math"
 	"time"
 
 	"github.com/intel-go/nff-go/common"
@@ -20,6 +21,12 @@ var now nowFuncT = func() time.Time { return time.Now() }
 // PcapGlobHdrSize is a size of cap global header.
 const PcapGlobHdrSize int64 = 24
 
+const pcapDefaultSnaplen = 65535
+
+// pcapZeroes is a source of zeroes for redacted bytes, so they are
+// written without changing packet and without allocations.
+var pcapZeroes [2048]byte
+
 // PcapGlobHdr is a Pcap global header.
 type PcapGlobHdr struct {
 	MagicNumber  uint32 /* magic number */
@@ -41,11 +48,21 @@ type PcapRecHdr struct {
 
 // WritePcapGlobalHdr writes global pcap header into file.
 func WritePcapGlobalHdr(f io.Writer) error {
+	return WritePcapGlobalHdrSnaplen(f, pcapDefaultSnaplen)
+}
+
+// WritePcapGlobalHdrSnaplen writes global pcap header with given
+// snaplen into file. It should be equal to snaplen which is used for
+// writing packets. Zero snaplen means default value 65535.
+func WritePcapGlobalHdrSnaplen(f io.Writer, snaplen uint32) error {
+	if snaplen == 0 {
+		snaplen = pcapDefaultSnaplen
+	}
 	glHdr := PcapGlobHdr{
 		MagicNumber:  0xa1b23c4d, // magic number for nanosecond-resolution pcap file
 		VersionMajor: 2,
 		VersionMinor: 4,
-		Snaplen:      65535,
+		Snaplen:      snaplen,
 		Network:      1,
 	}
 	if err := binary.Write(f, binary.LittleEndian, &glHdr); err != nil {
@@ -58,19 +75,63 @@ func WritePcapGlobalHdr(f io.Writer) error {
 // Assumes global pcap header is already present in file. Packet timestamps have nanosecond resolution.
 func (pkt *Packet) WritePcapOnePacket(f io.Writer) error {
 	bytes := low.GetRawPacketBytesMbuf(pkt.CMbuf)
-	if err := writePcapRecHdr(f, bytes); err != nil {
+	if err := writePcapRecHdr(f, bytes, len(bytes)); err != nil {
 		return err
 	}
 	return writePacketBytes(f, bytes)
 }
 
-func writePcapRecHdr(f io.Writer, pktBytes []byte) error {
+// WritePcapOnePacketSnaplen writes one packet with pcap header in file
+// saving no more than snaplen first bytes of packet. Original length
+// of packet is kept in pcap header. Zero snaplen means default value
+// 65535 like in WritePcapGlobalHdrSnaplen.
+// Assumes global pcap header is already present in file.
+func (pkt *Packet) WritePcapOnePacketSnaplen(f io.Writer, snaplen uint32) error {
+	return pkt.WritePcapOnePacketRedacted(f, snaplen, math.MaxUint32)
+}
+
+// WritePcapOnePacketRedacted writes one packet with pcap header in file
+// like WritePcapOnePacketSnaplen does, but all saved bytes starting
+// from redactOffset are written as zeroes. It allows to keep headers
+// while hiding payload, GetPacketPayloadOffset returns offset of
+// payload. Packet itself is not changed.
+func (pkt *Packet) WritePcapOnePacketRedacted(f io.Writer, snaplen, redactOffset uint32) error {
+	if snaplen == 0 {
+		snaplen = pcapDefaultSnaplen
+	}
+	bytes := low.GetRawPacketBytesMbuf(pkt.CMbuf)
+	origLen := len(bytes)
+	if uint32(origLen) > snaplen {
+		bytes = bytes[:snaplen]
+	}
+	if err := writePcapRecHdr(f, bytes, origLen); err != nil {
+		return err
+	}
+	if uint32(len(bytes)) <= redactOffset {
+		return writePacketBytes(f, bytes)
+	}
+	if err := writePacketBytes(f, bytes[:redactOffset]); err != nil {
+		return err
+	}
+	for left := len(bytes) - int(redactOffset); left > 0; left -= len(pcapZeroes) {
+		n := left
+		if n > len(pcapZeroes) {
+			n = len(pcapZeroes)
+		}
+		if err := writePacketBytes(f, pcapZeroes[:n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePcapRecHdr(f io.Writer, pktBytes []byte, origLen int) error {
 	t := now()
 	hdr := PcapRecHdr{
 		TsSec:   uint32(t.Unix()),
 		TsUsec:  uint32(t.UnixNano() % 1e9),
 		InclLen: uint32(len(pktBytes)),
-		OrigLen: uint32(len(pktBytes)),
+		OrigLen: uint32(origLen),
 	}
 	if err := binary.Write(f, binary.LittleEndian, &hdr); err != nil {
 		return common.WrapWithNFError(err, "write pcap header failed", common.PcapWriteFail)
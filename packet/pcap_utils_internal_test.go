@@ -59,6 +59,20 @@ func TestWritePcapGlobalHdr(t *testing.T) {
 	if !reflect.DeepEqual(buffer, wantBuffer) {
 		t.Errorf("Incorrect result:\ngot:  %x, \nwant: %x\n\n", buffer, wantBuffer)
 	}
+
+	buffer.Reset()
+	if err := WritePcapGlobalHdrSnaplen(buffer, 128); err != nil {
+		log.Fatal(err)
+	}
+	var gotGlobHdr PcapGlobHdr
+	if err := ReadPcapGlobalHdr(buffer, &gotGlobHdr); err != nil {
+		log.Fatal(err)
+	}
+	wantGlobHdr := globHdr
+	wantGlobHdr.Snaplen = 128
+	if gotGlobHdr != wantGlobHdr {
+		t.Errorf("Incorrect result:\ngot:  %+v, \nwant: %+v\n\n", gotGlobHdr, wantGlobHdr)
+	}
 }
 
 func TestReadPcapGlobalHdr(t *testing.T) {
@@ -91,6 +105,65 @@ func TestWritePcapOnePacket(t *testing.T) {
 	}
 }
 
+func TestWritePcapOnePacketSnaplen(t *testing.T) {
+	pkt := getIPv6ICMPTestPacket()
+	snaplen := uint32(common.EtherLen + common.IPv6Len + common.ICMPLen)
+	pktBuffer := append([]byte{}, pkt.GetRawPacketBytes()...)
+
+	buffer := bytes.NewBuffer([]byte{})
+	err := pkt.WritePcapOnePacketSnaplen(buffer, snaplen)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var hdr PcapRecHdr
+	if err := readPcapRecHdr(buffer, &hdr); err != nil {
+		log.Fatal(err)
+	}
+	if hdr.InclLen != snaplen || hdr.OrigLen != recHdr.OrigLen {
+		t.Errorf("Incorrect lengths:\ngot:  %d/%d, \nwant: %d/%d\n\n", hdr.InclLen, hdr.OrigLen, snaplen, recHdr.OrigLen)
+	}
+	if !reflect.DeepEqual(buffer.Bytes(), pktBuffer[:snaplen]) {
+		t.Errorf("Incorrect result:\ngot:  %x, \nwant: %x\n\n", buffer.Bytes(), pktBuffer[:snaplen])
+	}
+
+	// Default (zero) snaplen and snaplen higher than packet length don't truncate packet
+	for _, snaplen := range []uint32{0, 1000} {
+		buffer.Reset()
+		if err := pkt.WritePcapOnePacketSnaplen(buffer, snaplen); err != nil {
+			log.Fatal(err)
+		}
+		wantBytes := append(append([]byte{}, recHdrBuffer...), pktBuffer...)
+		if !bytes.Equal(buffer.Bytes(), wantBytes) {
+			t.Errorf("Incorrect result for snaplen %d:\ngot:  %x, \nwant: %x\n\n", snaplen, buffer.Bytes(), wantBytes)
+		}
+	}
+
+	// Payload after headers is zeroed while headers and lengths are kept
+	payloadOffset, ok := pkt.GetPacketPayloadOffset()
+	if !ok || payloadOffset != common.EtherLen+common.IPv6Len+common.ICMPLen {
+		t.Fatalf("Incorrect payload offset:\ngot:  %d (%t), \nwant: %d\n\n", payloadOffset, ok, common.EtherLen+common.IPv6Len+common.ICMPLen)
+	}
+	snaplen = 100
+	buffer.Reset()
+	if err := pkt.WritePcapOnePacketRedacted(buffer, snaplen, uint32(payloadOffset)); err != nil {
+		log.Fatal(err)
+	}
+	if err := readPcapRecHdr(buffer, &hdr); err != nil {
+		log.Fatal(err)
+	}
+	if hdr.InclLen != snaplen || hdr.OrigLen != recHdr.OrigLen {
+		t.Errorf("Incorrect lengths:\ngot:  %d/%d, \nwant: %d/%d\n\n", hdr.InclLen, hdr.OrigLen, snaplen, recHdr.OrigLen)
+	}
+	wantBytes := append(append([]byte{}, pktBuffer[:payloadOffset]...), make([]byte, snaplen-uint32(payloadOffset))...)
+	if !bytes.Equal(buffer.Bytes(), wantBytes) {
+		t.Errorf("Incorrect redacted result:\ngot:  %x, \nwant: %x\n\n", buffer.Bytes(), wantBytes)
+	}
+	if !bytes.Equal(pkt.GetRawPacketBytes(), pktBuffer) {
+		t.Error("Packet shouldn't be changed by redaction")
+	}
+}
+
 // Test fro internal readPcapRecHdr
 func TestReadPcapRecHdr(t *testing.T) {
 	wantHdr := recHdr
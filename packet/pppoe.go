@@ -0,0 +1,196 @@
+// Copyright 2019 Intel Corporation.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package packet
+
+import (
+	"fmt"
+	"unsafe"
+
+	. "github.com/intel-go/nff-go/common"
+)
+
+// PPPoEHdr is PPPoE session stage header (RFC 2516) followed by PPP
+// protocol field. We interpret it as an addition after EtherHdr
+// structure or after VLANHdr if packet is tagged, while
+// EtherType=0x8864 is present in the preceding header.
+type PPPoEHdr struct {
+	VersionType uint8  // Version and Type bit-fields, both are equal to 1
+	Code        uint8  // Always zero in session stage
+	SessionID   uint16 // PPPoE session identifier
+	Length      uint16 // Length of PPP payload including Protocol field
+	Protocol    uint16 // PPP protocol of encapsulated packet
+}
+
+// PPPoE session header values and PPP protocols
+const (
+	PPPoEVersionType = 0x11
+	PPPoECodeSession = 0
+
+	PPPIPv4Number = 0x0021
+	PPPIPv6Number = 0x0057
+)
+
+func (hdr *PPPoEHdr) String() string {
+	return fmt.Sprintf(`L2 PPPoE:
+Session ID: 0x%04x, Length: %d
+PPP protocol: 0x%04x`, SwapBytesUint16(hdr.SessionID),
+		SwapBytesUint16(hdr.Length), SwapBytesUint16(hdr.Protocol))
+}
+
+// GetPPPoESessionID returns PPPoE session identifier in host byte order.
+func (hdr *PPPoEHdr) GetPPPoESessionID() uint16 {
+	return SwapBytesUint16(hdr.SessionID)
+}
+
+// SetPPPoESessionID sets PPPoE session identifier.
+func (hdr *PPPoEHdr) SetPPPoESessionID(id uint16) {
+	hdr.SessionID = SwapBytesUint16(id)
+}
+
+// getPPPoEOffset returns offset of PPPoE session header from start of
+// packet taking possible presence of VLAN header before it into
+// account. Zero is returned if there is no PPPoE session header or if
+// its version, type or code are not the expected ones.
+func (packet *Packet) getPPPoEOffset() uintptr {
+	offset := uintptr(EtherLen)
+	etherType := packet.Ether.EtherType
+	if etherType == SwapBytesUint16(VLANNumber) {
+		etherType = packet.GetVLANNoCheck().EtherType
+		offset += VLANLen
+	}
+	if etherType != SwapBytesUint16(PPPoESessionNumber) {
+		return 0
+	}
+	hdr := (*PPPoEHdr)(packet.StartAtOffset(offset))
+	if hdr.VersionType != PPPoEVersionType || hdr.Code != PPPoECodeSession {
+		return 0
+	}
+	return offset
+}
+
+// GetPPPoE returns PPPoE session header pointer if it is present in the
+// packet. VLAN header before PPPoE header is taken into account.
+func (packet *Packet) GetPPPoE() *PPPoEHdr {
+	if offset := packet.getPPPoEOffset(); offset != 0 {
+		return (*PPPoEHdr)(packet.StartAtOffset(offset))
+	}
+	return nil
+}
+
+// GetPPPoENoCheck casts pointer to memory right after Ethernet header
+// to PPPoEHdr type. Possible VLAN header is not taken into account.
+func (packet *Packet) GetPPPoENoCheck() *PPPoEHdr {
+	return (*PPPoEHdr)(unsafe.Pointer(packet.unparsed()))
+}
+
+// ParseL3CheckPPPoE set pointer to start of L3 header taking possible
+// presence of VLAN and PPPoE session headers into account. Returns
+// PPPoE header or nil if it is not present.
+func (packet *Packet) ParseL3CheckPPPoE() *PPPoEHdr {
+	if offset := packet.getPPPoEOffset(); offset != 0 {
+		packet.L3 = packet.StartAtOffset(offset + PPPoELen)
+		return (*PPPoEHdr)(packet.StartAtOffset(offset))
+	}
+	packet.ParseL3CheckVLAN()
+	return nil
+}
+
+// GetIPv4CheckPPPoE ensures if L3 protocol is IPv4 and casts L3
+// pointer to IPv4Hdr type. VLAN and PPPoE presence is checked if
+// necessary.
+func (packet *Packet) GetIPv4CheckPPPoE() *IPv4Hdr {
+	if pppoe := packet.GetPPPoE(); pppoe != nil {
+		if pppoe.Protocol == SwapBytesUint16(PPPIPv4Number) {
+			return (*IPv4Hdr)(packet.L3)
+		}
+		return nil
+	}
+	return packet.GetIPv4CheckVLAN()
+}
+
+// GetIPv6CheckPPPoE ensures if L3 protocol is IPv6 and casts L3
+// pointer to IPv6Hdr type. VLAN and PPPoE presence is checked if
+// necessary.
+func (packet *Packet) GetIPv6CheckPPPoE() *IPv6Hdr {
+	if pppoe := packet.GetPPPoE(); pppoe != nil {
+		if pppoe.Protocol == SwapBytesUint16(PPPIPv6Number) {
+			return (*IPv6Hdr)(packet.L3)
+		}
+		return nil
+	}
+	return packet.GetIPv6CheckVLAN()
+}
+
+// getLastEtherType returns pointer to EtherType field which is
+// followed by L3 header: the one of Ethernet header or of VLAN header
+// if it is present. Second returned value is offset of L3 header.
+func (packet *Packet) getLastEtherType() (*uint16, uint) {
+	if packet.Ether.EtherType == SwapBytesUint16(VLANNumber) {
+		return &packet.GetVLANNoCheck().EtherType, EtherLen + VLANLen
+	}
+	return &packet.Ether.EtherType, EtherLen
+}
+
+// AddPPPoE increases size of packet on PPPoELen and adds PPPoE session
+// header after Ether header or after VLAN header if it is present. PPP
+// protocol is taken from EtherType which should be IPv4 or IPv6. PPPoE
+// length is taken from L3 header, so Ethernet padding is not counted.
+// Returns false if error.
+func (packet *Packet) AddPPPoE(sessionID uint16) bool {
+	etherType, offset := packet.getLastEtherType()
+	l3 := packet.StartAtOffset(uintptr(offset))
+	var protocol, length uint16
+	switch *etherType {
+	case SwapIPV4Number:
+		protocol = PPPIPv4Number
+		length = SwapBytesUint16((*IPv4Hdr)(l3).TotalLength)
+	case SwapIPV6Number:
+		protocol = PPPIPv6Number
+		length = SwapBytesUint16((*IPv6Hdr)(l3).PayloadLen) + IPv6Len
+	default:
+		return false
+	}
+	if !packet.EncapsulateHead(offset, PPPoELen) {
+		return false
+	}
+	// EncapsulateHead function has moved Ethernet and VLAN headers,
+	// so EtherType pointer should be taken again.
+	etherType, _ = packet.getLastEtherType()
+	*etherType = SwapBytesUint16(PPPoESessionNumber)
+	hdr := (*PPPoEHdr)(packet.StartAtOffset(uintptr(offset)))
+	hdr.VersionType = PPPoEVersionType
+	hdr.Code = PPPoECodeSession
+	hdr.SessionID = SwapBytesUint16(sessionID)
+	hdr.Length = SwapBytesUint16(length + 2)
+	hdr.Protocol = SwapBytesUint16(protocol)
+	return true
+}
+
+// RemovePPPoE decreases size of packet on PPPoELen and restores
+// EtherType according to PPP protocol. VLAN header before PPPoE header
+// is kept. Returns false if there is no PPPoE session header or PPP
+// protocol is neither IPv4 nor IPv6.
+func (packet *Packet) RemovePPPoE() bool {
+	offset := packet.getPPPoEOffset()
+	if offset == 0 {
+		return false
+	}
+	hdr := (*PPPoEHdr)(packet.StartAtOffset(offset))
+	var etherType uint16
+	switch SwapBytesUint16(hdr.Protocol) {
+	case PPPIPv4Number:
+		etherType = IPV4Number
+	case PPPIPv6Number:
+		etherType = IPV6Number
+	default:
+		return false
+	}
+	if !packet.DecapsulateHead(uint(offset), PPPoELen) {
+		return false
+	}
+	lastEtherType, _ := packet.getLastEtherType()
+	*lastEtherType = SwapBytesUint16(etherType)
+	return true
+}
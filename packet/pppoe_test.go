@@ -0,0 +1,153 @@
+// Copyright 2019 Intel Corporation.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package packet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func init() {
+	tInitDPDK()
+}
+
+// Same IPv4 TCP packet as gtLineIPv4TCPVLAN encapsulated in PPPoE
+// session 0x1234 instead of VLAN
+var (
+	gtLineIPv4TCPPPPoE = "00400540ef240060089fb1f3886411001234002a0021" +
+		"450000288a1b00004006000083972015839720811770048a0000000100000d9550107c7000000000"
+	gtLineIPv4TCPNoPPPoE = "00400540ef240060089fb1f30800" +
+		"450000288a1b00004006000083972015839720811770048a0000000100000d9550107c7000000000"
+	// PPPoE session inside VLAN 32
+	gtLineIPv4TCPVLANPPPoE = "00400540ef240060089fb1f3810000208864" + "11001234002a0021" +
+		"450000288a1b00004006000083972015839720811770048a0000000100000d9550107c7000000000"
+)
+
+func TestParseL3CheckPPPoE(t *testing.T) {
+	buf, _ := hex.DecodeString(gtLineIPv4TCPPPPoE)
+	pkt := getPacket()
+	GeneratePacketFromByte(pkt, buf)
+
+	pppoe := pkt.ParseL3CheckPPPoE()
+	if pppoe == nil {
+		t.Fatal("PPPoE header was not recognized")
+	}
+	if pppoe.GetPPPoESessionID() != 0x1234 {
+		t.Errorf("Incorrect session ID:\ngot:  0x%x, \nwant: 0x1234\n\n", pppoe.GetPPPoESessionID())
+	}
+	if pkt.GetIPv6CheckPPPoE() != nil {
+		t.Error("IPv4 packet was recognized as IPv6")
+	}
+	ipv4 := pkt.GetIPv4CheckPPPoE()
+	if ipv4 == nil {
+		t.Fatal("IPv4 header inside PPPoE was not recognized")
+	}
+	if *ipv4 != IPv4HeaderVLAN {
+		t.Errorf("Incorrect IPv4 header:\ngot:  %+v, \nwant: %+v\n\n", *ipv4, IPv4HeaderVLAN)
+	}
+	pkt.ParseL4ForIPv4()
+	tcp := pkt.GetTCPForIPv4()
+	if tcp == nil {
+		t.Fatal("TCP header inside PPPoE was not recognized")
+	}
+	if *tcp != TCPHeaderVLAN {
+		t.Errorf("Incorrect TCP header:\ngot:  %+v, \nwant: %+v\n\n", *tcp, TCPHeaderVLAN)
+	}
+}
+
+func TestAddRemovePPPoE(t *testing.T) {
+	gtPPPoE, _ := hex.DecodeString(gtLineIPv4TCPPPPoE)
+	gtNoPPPoE, _ := hex.DecodeString(gtLineIPv4TCPNoPPPoE)
+	pkt := getPacket()
+	GeneratePacketFromByte(pkt, gtPPPoE)
+
+	if !pkt.RemovePPPoE() {
+		t.Fatal("RemovePPPoE failed")
+	}
+	if got := pkt.GetRawPacketBytes(); !bytes.Equal(got, gtNoPPPoE) {
+		t.Errorf("Incorrect result after removing PPPoE:\ngot:  %x, \nwant: %x\n\n", got, gtNoPPPoE)
+	}
+	if pkt.RemovePPPoE() {
+		t.Error("RemovePPPoE should fail without PPPoE header")
+	}
+
+	if !pkt.AddPPPoE(0x1234) {
+		t.Fatal("AddPPPoE failed")
+	}
+	if got := pkt.GetRawPacketBytes(); !bytes.Equal(got, gtPPPoE) {
+		t.Errorf("Incorrect result after adding PPPoE:\ngot:  %x, \nwant: %x\n\n", got, gtPPPoE)
+	}
+}
+
+func TestPPPoEOverVLAN(t *testing.T) {
+	gtPPPoE, _ := hex.DecodeString(gtLineIPv4TCPVLANPPPoE)
+	gtVLAN, _ := hex.DecodeString(gtLineIPv4TCPVLAN)
+	pkt := getPacket()
+	GeneratePacketFromByte(pkt, gtPPPoE)
+
+	pppoe := pkt.ParseL3CheckPPPoE()
+	if pppoe == nil || pppoe.GetPPPoESessionID() != 0x1234 {
+		t.Fatalf("PPPoE header after VLAN header was not recognized: %v", pppoe)
+	}
+	if ipv4 := pkt.GetIPv4CheckPPPoE(); ipv4 == nil || *ipv4 != IPv4HeaderVLAN {
+		t.Fatalf("Incorrect IPv4 header:\ngot:  %+v, \nwant: %+v\n\n", ipv4, IPv4HeaderVLAN)
+	}
+
+	if !pkt.RemovePPPoE() {
+		t.Fatal("RemovePPPoE failed")
+	}
+	if got := pkt.GetRawPacketBytes(); !bytes.Equal(got, gtVLAN) {
+		t.Errorf("Incorrect result after removing PPPoE:\ngot:  %x, \nwant: %x\n\n", got, gtVLAN)
+	}
+	if !pkt.AddPPPoE(0x1234) {
+		t.Fatal("AddPPPoE failed")
+	}
+	if got := pkt.GetRawPacketBytes(); !bytes.Equal(got, gtPPPoE) {
+		t.Errorf("Incorrect result after adding PPPoE:\ngot:  %x, \nwant: %x\n\n", got, gtPPPoE)
+	}
+}
+
+func TestAddPPPoEPaddedFrame(t *testing.T) {
+	// 60 bytes IPv4 UDP frame with 18 bytes of Ethernet padding
+	const padded = "4500001c00000000401100008397201583972081" + "04d2162e00080000" +
+		"000000000000000000000000000000000000"
+	buf, _ := hex.DecodeString("00400540ef240060089fb1f30800" + padded)
+	want, _ := hex.DecodeString("00400540ef240060089fb1f38864" + "11001234001e0021" + padded)
+	pkt := getPacket()
+	GeneratePacketFromByte(pkt, buf)
+
+	if !pkt.AddPPPoE(0x1234) {
+		t.Fatal("AddPPPoE failed")
+	}
+	if got := pkt.GetRawPacketBytes(); !bytes.Equal(got, want) {
+		t.Errorf("Incorrect result:\ngot:  %x, \nwant: %x\n\n", got, want)
+	}
+}
+
+func TestParseL3CheckPPPoEMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		hdr  string
+	}{
+		{"unexpected version", "21001234002a0021"},
+		{"discovery code", "11091234002a0021"},
+	}
+	for _, test := range tests {
+		buf, _ := hex.DecodeString("00400540ef240060089fb1f38864" + test.hdr +
+			"450000288a1b00004006000083972015839720811770048a0000000100000d9550107c7000000000")
+		pkt := getPacket()
+		GeneratePacketFromByte(pkt, buf)
+		if pkt.ParseL3CheckPPPoE() != nil {
+			t.Errorf("%s: PPPoE header shouldn't be accepted", test.name)
+		}
+		if pkt.GetIPv4CheckPPPoE() != nil {
+			t.Errorf("%s: IPv4 header shouldn't be found", test.name)
+		}
+		if pkt.RemovePPPoE() {
+			t.Errorf("%s: RemovePPPoE shouldn't succeed", test.name)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2019 Intel Corporation.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package packet
+
+import (
+	"encoding/binary"
+	"unsafe"
+
+	. "github.com/intel-go/nff-go/common"
+)
+
+// TCP option kinds
+const (
+	TCPOptionEndOfList     = 0
+	TCPOptionNoOperation   = 1
+	TCPOptionMSS           = 2
+	TCPOptionWindowScale   = 3
+	TCPOptionSACKPermitted = 4
+	TCPOptionSACK          = 5
+	TCPOptionTimestamps    = 8
+)
+
+// TCPMaxOptionsLen is a maximum length of TCP options area, it is
+// limited by 4 bits of TCP data offset field.
+const TCPMaxOptionsLen = 40
+
+// TCPOption is one option of TCP header. Length is a whole option
+// length including kind and length bytes. Value points to option
+// value inside packet, so changing it changes packet.
+type TCPOption struct {
+	Kind   uint8
+	Length uint8
+	Value  []byte
+}
+
+// GetTCPHdrLen returns length of TCP header including options taken
+// from data offset field.
+func (hdr *TCPHdr) GetTCPHdrLen() uint {
+	return uint(hdr.DataOff&0xf0) >> 2
+}
+
+// GetTCPOptionsBytes returns TCP options area which is located
+// between minimal TCP header and data. Returned slice points to
+// packet memory. Returns nil if there are no options.
+//
+// Length of options area is taken from data offset field only and is
+// not checked against packet length, so this and other option
+// functions read and write past the end of truncated packet. Callers
+// should ensure that packet holds whole TCP header first, e.g. with
+// ParseAllKnownL4ForIPv4CheckLen.
+func (hdr *TCPHdr) GetTCPOptionsBytes() []byte {
+	hdrLen := hdr.GetTCPHdrLen()
+	if hdrLen <= TCPMinLen {
+		return nil
+	}
+	length := hdrLen - TCPMinLen
+	return (*[TCPMaxOptionsLen]byte)(unsafe.Pointer(uintptr(unsafe.Pointer(hdr)) + TCPMinLen))[:length:length]
+}
+
+// getTCPOptionLen returns length of option which starts at offset of
+// options area. End of list option takes all remaining space. Zero is
+// returned if option is malformed or truncated.
+func getTCPOptionLen(opts []byte, offset int) int {
+	switch opts[offset] {
+	case TCPOptionEndOfList:
+		return len(opts) - offset
+	case TCPOptionNoOperation:
+		return 1
+	}
+	if offset+1 >= len(opts) {
+		return 0
+	}
+	length := int(opts[offset+1])
+	if length < 2 || offset+length > len(opts) {
+		return 0
+	}
+	return length
+}
+
+// findTCPOption returns offset and length of first option with given
+// kind. Last returned value is false if option isn't found or
+// options are malformed.
+func findTCPOption(opts []byte, kind uint8) (int, int, bool) {
+	for offset := 0; offset < len(opts); {
+		length := getTCPOptionLen(opts, offset)
+		if length == 0 || opts[offset] == TCPOptionEndOfList {
+			break
+		}
+		if opts[offset] == kind {
+			return offset, length, true
+		}
+		offset += length
+	}
+	return 0, 0, false
+}
+
+// ParseTCPOptions appends all TCP options of header except padding
+// (no operation and end of list options) to opts and returns result.
+// Passing previously returned slice allows to avoid allocations.
+// Second returned value is false if data offset is less than minimal
+// TCP header length or some option has incorrect length or doesn't
+// fit in header. Options parsed before error are still returned.
+func (hdr *TCPHdr) ParseTCPOptions(opts []TCPOption) ([]TCPOption, bool) {
+	opts = opts[:0]
+	if hdr.GetTCPHdrLen() < TCPMinLen {
+		return opts, false
+	}
+	bytes := hdr.GetTCPOptionsBytes()
+	for offset := 0; offset < len(bytes); {
+		length := getTCPOptionLen(bytes, offset)
+		if length == 0 {
+			return opts, false
+		}
+		switch bytes[offset] {
+		case TCPOptionEndOfList:
+			return opts, true
+		case TCPOptionNoOperation:
+		default:
+			opts = append(opts, TCPOption{
+				Kind:   bytes[offset],
+				Length: uint8(length),
+				Value:  bytes[offset+2 : offset+length],
+			})
+		}
+		offset += length
+	}
+	return opts, true
+}
+
+// GetTCPOption returns first TCP option of given kind. Second
+// returned value is false if there is no such option.
+func (hdr *TCPHdr) GetTCPOption(kind uint8) (TCPOption, bool) {
+	bytes := hdr.GetTCPOptionsBytes()
+	offset, length, ok := findTCPOption(bytes, kind)
+	if !ok {
+		return TCPOption{}, false
+	}
+	return TCPOption{
+		Kind:   kind,
+		Length: uint8(length),
+		Value:  bytes[offset+2 : offset+length],
+	}, true
+}
+
+// SetTCPOption rewrites value of first TCP option of given kind in
+// place. New value can't be longer than old one. If it is shorter,
+// freed bytes are filled with no operation options, so header length
+// is not changed. Returns false if there is no such option or value
+// doesn't fit. TCP checksum should be recalculated after this.
+func (hdr *TCPHdr) SetTCPOption(kind uint8, value []byte) bool {
+	bytes := hdr.GetTCPOptionsBytes()
+	offset, length, ok := findTCPOption(bytes, kind)
+	if !ok || len(value)+2 > length {
+		return false
+	}
+	bytes[offset+1] = uint8(len(value) + 2)
+	copy(bytes[offset+2:], value)
+	for i := offset + 2 + len(value); i < offset+length; i++ {
+		bytes[i] = TCPOptionNoOperation
+	}
+	return true
+}
+
+// RemoveTCPOption replaces first TCP option of given kind with no
+// operation options, so header length is not changed. Returns false
+// if there is no such option. TCP checksum should be recalculated
+// after this.
+func (hdr *TCPHdr) RemoveTCPOption(kind uint8) bool {
+	bytes := hdr.GetTCPOptionsBytes()
+	offset, length, ok := findTCPOption(bytes, kind)
+	if !ok {
+		return false
+	}
+	for i := offset; i < offset+length; i++ {
+		bytes[i] = TCPOptionNoOperation
+	}
+	return true
+}
+
+// GetTCPOptionMSS returns value of maximum segment size option.
+func (hdr *TCPHdr) GetTCPOptionMSS() (uint16, bool) {
+	opt, ok := hdr.GetTCPOption(TCPOptionMSS)
+	if !ok || len(opt.Value) != 2 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(opt.Value), true
+}
+
+// GetTCPOptionWindowScale returns shift count of window scale option.
+func (hdr *TCPHdr) GetTCPOptionWindowScale() (uint8, bool) {
+	opt, ok := hdr.GetTCPOption(TCPOptionWindowScale)
+	if !ok || len(opt.Value) != 1 {
+		return 0, false
+	}
+	return opt.Value[0], true
+}
+
+// GetTCPOptionSACKPermitted returns true if SACK permitted option is
+// present.
+func (hdr *TCPHdr) GetTCPOptionSACKPermitted() bool {
+	opt, ok := hdr.GetTCPOption(TCPOptionSACKPermitted)
+	return ok && len(opt.Value) == 0
+}
+
+// GetTCPOptionTimestamps returns timestamp value and timestamp echo
+// reply of timestamps option.
+func (hdr *TCPHdr) GetTCPOptionTimestamps() (uint32, uint32, bool) {
+	opt, ok := hdr.GetTCPOption(TCPOptionTimestamps)
+	if !ok || len(opt.Value) != 8 {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint32(opt.Value), binary.BigEndian.Uint32(opt.Value[4:]), true
+}
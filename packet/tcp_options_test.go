@@ -0,0 +1,144 @@
+// Copyright 2019 Intel Corporation.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package packet
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/intel-go/nff-go/common"
+)
+
+func init() {
+	tInitDPDK()
+}
+
+// getTCPWithOptions returns TCP header of IPv4 packet which has
+// given options after minimal TCP header.
+func getTCPWithOptions(options []byte) *TCPHdr {
+	pkt := getPacket()
+	InitEmptyIPv4TCPPacket(pkt, uint(len(options)))
+	tcp := pkt.GetTCPNoCheck()
+	tcp.DataOff = uint8((TCPMinLen+len(options))/4) << 4
+	copy(tcp.GetTCPOptionsBytes(), options)
+	return tcp
+}
+
+// SYN options as sent by Linux: MSS, SACK permitted, timestamps, NOP, window scale
+var synOptions = []byte{0x02, 0x04, 0x05, 0xb4, 0x04, 0x02, 0x08, 0x0a, 0x00, 0x00, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x02, 0x01, 0x03, 0x03, 0x07}
+
+func TestParseTCPOptions(t *testing.T) {
+	tcp := getTCPWithOptions(synOptions)
+
+	opts, ok := tcp.ParseTCPOptions(nil)
+	if !ok {
+		t.Fatal("Correct options were reported as malformed")
+	}
+	wantKinds := []uint8{TCPOptionMSS, TCPOptionSACKPermitted, TCPOptionTimestamps, TCPOptionWindowScale}
+	if len(opts) != len(wantKinds) {
+		t.Fatalf("Incorrect number of options:\ngot:  %d, \nwant: %d\n\n", len(opts), len(wantKinds))
+	}
+	for i := range wantKinds {
+		if opts[i].Kind != wantKinds[i] {
+			t.Errorf("Incorrect option %d kind:\ngot:  %d, \nwant: %d\n\n", i, opts[i].Kind, wantKinds[i])
+		}
+		if int(opts[i].Length) != len(opts[i].Value)+2 {
+			t.Errorf("Option %d length %d doesn't match value %x", i, opts[i].Length, opts[i].Value)
+		}
+	}
+
+	if mss, ok := tcp.GetTCPOptionMSS(); !ok || mss != 1460 {
+		t.Errorf("Incorrect MSS:\ngot:  %d (%t), \nwant: 1460\n\n", mss, ok)
+	}
+	if ws, ok := tcp.GetTCPOptionWindowScale(); !ok || ws != 7 {
+		t.Errorf("Incorrect window scale:\ngot:  %d (%t), \nwant: 7\n\n", ws, ok)
+	}
+	if !tcp.GetTCPOptionSACKPermitted() {
+		t.Error("SACK permitted option wasn't found")
+	}
+	if val, ecr, ok := tcp.GetTCPOptionTimestamps(); !ok || val != 1 || ecr != 2 {
+		t.Errorf("Incorrect timestamps:\ngot:  %d %d (%t), \nwant: 1 2\n\n", val, ecr, ok)
+	}
+	if _, ok := tcp.GetTCPOption(TCPOptionSACK); ok {
+		t.Error("SACK option shouldn't be found")
+	}
+}
+
+func TestParseTCPOptionsEndOfList(t *testing.T) {
+	// MSS followed by end of list and garbage which should be ignored
+	tcp := getTCPWithOptions([]byte{0x02, 0x04, 0x05, 0xb4, 0x00, 0x03, 0x03, 0x07})
+
+	opts, ok := tcp.ParseTCPOptions(nil)
+	if !ok || len(opts) != 1 || opts[0].Kind != TCPOptionMSS {
+		t.Errorf("Incorrect result:\ngot:  %+v (%t), \nwant: only MSS option\n\n", opts, ok)
+	}
+	if _, ok := tcp.GetTCPOptionWindowScale(); ok {
+		t.Error("Window scale after end of list option shouldn't be found")
+	}
+}
+
+func TestParseTCPOptionsMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []byte
+	}{
+		{"length exceeds header", []byte{0x02, 0x05, 0x05, 0xb4}},
+		{"length less than two", []byte{0x08, 0x01, 0x01, 0x01}},
+		{"truncated length byte", []byte{0x01, 0x01, 0x01, 0x02}},
+		{"truncated after valid option", []byte{0x02, 0x04, 0x05, 0xb4, 0x08, 0x0a, 0x00, 0x00}},
+	}
+	for _, test := range tests {
+		tcp := getTCPWithOptions(test.options)
+		if _, ok := tcp.ParseTCPOptions(nil); ok {
+			t.Errorf("%s: malformed options were reported as correct", test.name)
+		}
+		if _, ok := tcp.GetTCPOption(TCPOptionTimestamps); ok {
+			t.Errorf("%s: option shouldn't be found in malformed options", test.name)
+		}
+	}
+
+	tcp := getTCPWithOptions(nil)
+	tcp.DataOff = 0x40
+	if _, ok := tcp.ParseTCPOptions(nil); ok {
+		t.Error("Data offset less than minimal header length should be reported as malformed")
+	}
+}
+
+func TestSetTCPOption(t *testing.T) {
+	tcp := getTCPWithOptions(synOptions)
+
+	if !tcp.SetTCPOption(TCPOptionMSS, []byte{0x05, 0x78}) {
+		t.Fatal("SetTCPOption failed for MSS")
+	}
+	if mss, _ := tcp.GetTCPOptionMSS(); mss != 1400 {
+		t.Errorf("Incorrect MSS:\ngot:  %d, \nwant: 1400\n\n", mss)
+	}
+	if tcp.SetTCPOption(TCPOptionWindowScale, []byte{0x01, 0x02}) {
+		t.Error("Longer value than original one shouldn't be set")
+	}
+
+	// Shorter value is padded with no operation options
+	if !tcp.SetTCPOption(TCPOptionTimestamps, []byte{0x00, 0x00, 0x00, 0x05}) {
+		t.Fatal("SetTCPOption failed for timestamps")
+	}
+	want := []byte{0x08, 0x06, 0x00, 0x00, 0x00, 0x05, 0x01, 0x01, 0x01, 0x01}
+	if got := tcp.GetTCPOptionsBytes()[6:16]; !bytes.Equal(got, want) {
+		t.Errorf("Incorrect result:\ngot:  %x, \nwant: %x\n\n", got, want)
+	}
+	if ws, ok := tcp.GetTCPOptionWindowScale(); !ok || ws != 7 {
+		t.Errorf("Window scale after rewritten option is broken:\ngot:  %d (%t), \nwant: 7\n\n", ws, ok)
+	}
+
+	if !tcp.RemoveTCPOption(TCPOptionSACKPermitted) {
+		t.Fatal("RemoveTCPOption failed")
+	}
+	if tcp.GetTCPOptionSACKPermitted() {
+		t.Error("Removed option is still present")
+	}
+	if tcp.GetTCPHdrLen() != TCPMinLen+uint(len(synOptions)) {
+		t.Errorf("Header length shouldn't be changed:\ngot:  %d, \nwant: %d\n\n", tcp.GetTCPHdrLen(), TCPMinLen+len(synOptions))
+	}
+}